@@ -0,0 +1,231 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// CloudSecretSpec defines the desired state of CloudSecret
+type CloudSecretSpec struct {
+	// SyncPeriod is how often, in seconds, the remote secrets are re-fetched.
+	SyncPeriod int64 `json:"syncPeriod"`
+
+	// Data maps a key in the resulting k8s Secret to the remote secret it's
+	// populated from, along with any transforms to apply to that secret's
+	// payload before it lands in the child Secret.
+	Data map[string]SecretDataEntry `json:"data,omitempty"`
+
+	// Selector, if set, discovers secrets by label filter instead of (or in
+	// addition to) listing them individually in Data.
+	Selector *SecretSelector `json:"selector,omitempty"`
+
+	// DeleteOnEmpty, when true, preserves the legacy behavior of deleting
+	// the child Secret once no keys resolve. When false (the default), a
+	// child Secret that already held data is left untouched so existing
+	// data survives a transient upstream outage, while a child Secret with
+	// no prior data is still deleted. Either way ConditionSecretsFetched/
+	// ConditionReady go False with the fetch failure's reason, or Empty if
+	// nothing failed, so operators can alert on the drift.
+	DeleteOnEmpty bool `json:"deleteOnEmpty,omitempty"`
+
+	// PurgeRemote, when true, disables the upstream secret version for
+	// every entry in Data whose provider supports purging (currently gcp)
+	// when the CloudSecret itself is deleted; entries of other providers
+	// are left intact upstream.
+	PurgeRemote bool `json:"purgeRemote,omitempty"`
+}
+
+// SecretDataEntry resolves a single child Secret key from a provider-scheme
+// secret reference, e.g. gcp://projects/my-proj/secrets/my-secret/versions/latest,
+// optionally transforming the payload before it's stored.
+type SecretDataEntry struct {
+	// Ref is the provider-scheme secret reference to fetch.
+	Ref string `json:"ref"`
+
+	// Decode, if set to "base64", base64-decodes the raw payload before
+	// any further transform is applied.
+	Decode string `json:"decode,omitempty"`
+
+	// JSONPath, if set, parses the (decoded) payload as JSON and extracts
+	// a dotted field path, e.g. "username" or "database.password". Useful
+	// when one remote secret stores multiple credentials as a JSON object.
+	JSONPath string `json:"jsonPath,omitempty"`
+
+	// Template, if set, is a Go template executed with the (decoded,
+	// extracted) payload as `.Payload` and every child Secret key resolved
+	// so far as `.Secrets`, letting one key be composed from others, e.g.
+	// "postgres://{{ .Secrets.username }}:{{ .Payload }}@db". Entries with
+	// Template are always resolved after every entry without one (and
+	// after Selector discovery), in lexical key order, so `.Secrets`
+	// references are deterministic: a Template entry sees the Template
+	// entries whose keys sort before its own. Referencing a key that has
+	// not resolved by then (one that failed, or a Template entry sorting
+	// after it) fails that key, marking the fetch as failed, rather than
+	// substituting a silent "<no value>".
+	Template string `json:"template,omitempty"`
+}
+
+// SecretSelector discovers secrets from a provider by label/tag filter
+// rather than by individual reference.
+type SecretSelector struct {
+	// Provider is the scheme of the provider to list secrets from, e.g. "gcp".
+	Provider string `json:"provider"`
+
+	// Filter is a provider-specific label filter, handed to the provider
+	// as is; for gcp it is Secret Manager's filter syntax, e.g.
+	// "labels.env=prod AND labels.app=api". A comma-separated list such as
+	// "env=prod,app=api" is not translated into that syntax.
+	Filter string `json:"filter"`
+
+	// KeyTemplate is a Go template, evaluated against each discovered
+	// secret's labels, that produces the key the secret is stored under in
+	// the child Secret, e.g. "{{ .Labels.k8sKey }}".
+	KeyTemplate string `json:"keyTemplate"`
+
+	// MaxSecrets bounds how many secrets a single reconcile will
+	// materialize, guarding against runaway child secrets. Defaults to 500.
+	MaxSecrets int64 `json:"maxSecrets,omitempty"`
+}
+
+// ConditionType is one of the condition kinds tracked in
+// CloudSecretStatus.Conditions.
+type ConditionType string
+
+const (
+	// ConditionReady summarizes overall reconcile health.
+	ConditionReady ConditionType = "Ready"
+	// ConditionSecretsFetched reflects whether every Data/Selector ref
+	// resolved successfully on the most recent reconcile.
+	ConditionSecretsFetched ConditionType = "SecretsFetched"
+	// ConditionChildSecretSynced reflects whether the child Secret was
+	// created/updated to match the fetched data.
+	ConditionChildSecretSynced ConditionType = "ChildSecretSynced"
+)
+
+// ConditionStatus is the tri-state value of a Condition, mirroring
+// corev1.ConditionStatus.
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+const (
+	// ReasonSecretNotFound means a referenced secret does not exist upstream.
+	ReasonSecretNotFound = "SecretNotFound"
+	// ReasonPermissionDenied means the provider rejected the access as unauthorized.
+	ReasonPermissionDenied = "PermissionDenied"
+	// ReasonProviderUnavailable means the provider/backend could not be reached.
+	ReasonProviderUnavailable = "ProviderUnavailable"
+	// ReasonEmpty means reconciliation succeeded but resolved zero keys.
+	ReasonEmpty = "Empty"
+	// ReasonSynced means the condition's concern completed successfully.
+	ReasonSynced = "Synced"
+)
+
+// Condition is a single observation of CloudSecret reconcile state.
+type Condition struct {
+	Type               ConditionType   `json:"type"`
+	Status             ConditionStatus `json:"status"`
+	Reason             string          `json:"reason,omitempty"`
+	Message            string          `json:"message,omitempty"`
+	LastTransitionTime metav1.Time     `json:"lastTransitionTime,omitempty"`
+}
+
+// CloudSecretStatus defines the observed state of CloudSecret
+type CloudSecretStatus struct {
+	// Conditions tracks the latest observed state of the reconciled
+	// CloudSecret, keyed by Type.
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// SetCondition upserts a condition by Type, bumping LastTransitionTime only
+// when Status actually changes.
+func (s *CloudSecretStatus) SetCondition(condType ConditionType, status ConditionStatus, reason, message string, now metav1.Time) {
+	for i := range s.Conditions {
+		c := &s.Conditions[i]
+		if c.Type != condType {
+			continue
+		}
+
+		if c.Status != status {
+			c.LastTransitionTime = now
+		}
+		c.Status = status
+		c.Reason = reason
+		c.Message = message
+		return
+	}
+
+	s.Conditions = append(s.Conditions, Condition{
+		Type:               condType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+	})
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// CloudSecret is the Schema for the cloudsecrets API
+type CloudSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CloudSecretSpec   `json:"spec,omitempty"`
+	Status CloudSecretStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CloudSecretList contains a list of CloudSecret
+type CloudSecretList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CloudSecret `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CloudSecret{}, &CloudSecretList{})
+}
+
+// GetChildSecretKey returns the namespaced name of the k8s Secret that this
+// CloudSecret materializes its data into. The child secret shares the
+// CloudSecret's name and namespace.
+func (c *CloudSecret) GetChildSecretKey() types.NamespacedName {
+	return types.NamespacedName{Name: c.Name, Namespace: c.Namespace}
+}
+
+// InitChildSecret builds a new, empty k8s Secret owned by this CloudSecret.
+func (c *CloudSecret) InitChildSecret() corev1.Secret {
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.Name,
+			Namespace: c.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(c, GroupVersion.WithKind("CloudSecret")),
+			},
+		},
+	}
+}
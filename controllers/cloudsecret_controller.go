@@ -16,36 +16,275 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"text/template"
 	"time"
 
-	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	smsecretsmanager "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	smithy "github.com/aws/smithy-go"
 	"github.com/go-logr/logr"
+	vaultapi "github.com/hashicorp/vault/api"
 	secretsv1 "github.com/masonwr/CloudSecret/api/v1"
-	secrets "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+	"github.com/masonwr/CloudSecret/providers"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const defaultRetryTime = time.Duration(5) * time.Second
 
+// cloudSecretFinalizer is set on every CloudSecret so that deletion is
+// intercepted to clean up the child Secret (and, if Spec.PurgeRemote is
+// set, the upstream secret versions) before the CR itself is removed.
+const cloudSecretFinalizer = "secrets.masonwr.dev/finalizer"
+
+// defaultMaxSelectorSecrets bounds Spec.Selector discovery when MaxSecrets
+// is unset, guarding against a single CloudSecret materializing an
+// unbounded number of keys into its child Secret.
+const defaultMaxSelectorSecrets = 500
+
 // CloudSecretReconciler reconciles a CloudSecret object
 type CloudSecretReconciler struct {
 	client.Client
-	Log        logr.Logger
-	Scheme     *runtime.Scheme
-	GcpSecrets *secretmanager.Client
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	Providers providers.Registry
+	Recorder  record.EventRecorder
+
+	// RemotePollInterval configures the RemotePoller registered in
+	// SetupWithManager; zero uses defaultRemotePollInterval.
+	RemotePollInterval time.Duration
+}
+
+// classifyFetchError maps a provider error to one of the well-known
+// condition/event reasons, recognizing the typed "not found"/"forbidden"
+// errors surfaced by each registered provider (GCP's gRPC status codes,
+// AWS's ResourceNotFoundException/AccessDeniedException, and the HTTP
+// status carried by Azure's and Vault's response errors). Anything else
+// falls back to ProviderUnavailable.
+func classifyFetchError(err error) string {
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.NotFound:
+			return secretsv1.ReasonSecretNotFound
+		case codes.PermissionDenied, codes.Unauthenticated:
+			return secretsv1.ReasonPermissionDenied
+		}
+	}
+
+	var awsNotFound *smsecretsmanager.ResourceNotFoundException
+	if errors.As(err, &awsNotFound) {
+		return secretsv1.ReasonSecretNotFound
+	}
+
+	var awsAPIErr smithy.APIError
+	if errors.As(err, &awsAPIErr) && awsAPIErr.ErrorCode() == "AccessDeniedException" {
+		return secretsv1.ReasonPermissionDenied
+	}
+
+	var azureErr *azcore.ResponseError
+	if errors.As(err, &azureErr) {
+		switch azureErr.StatusCode {
+		case http.StatusNotFound:
+			return secretsv1.ReasonSecretNotFound
+		case http.StatusForbidden, http.StatusUnauthorized:
+			return secretsv1.ReasonPermissionDenied
+		}
+	}
+
+	var vaultErr *vaultapi.ResponseError
+	if errors.As(err, &vaultErr) {
+		switch vaultErr.StatusCode {
+		case http.StatusNotFound:
+			return secretsv1.ReasonSecretNotFound
+		case http.StatusForbidden, http.StatusUnauthorized:
+			return secretsv1.ReasonPermissionDenied
+		}
+	}
+
+	return secretsv1.ReasonProviderUnavailable
+}
+
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(slice []string, s string) []string {
+	out := make([]string, 0, len(slice))
+	for _, item := range slice {
+		if item != s {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// finalizeCloudSecret deletes the child Secret and, if Spec.PurgeRemote is
+// set, disables every upstream secret version referenced in Spec.Data whose
+// provider implements providers.SecretPurger; refs of other providers are
+// skipped. It is called once, just before cloudSecretFinalizer is removed.
+func (r *CloudSecretReconciler) finalizeCloudSecret(ctx context.Context, cloudSecret *secretsv1.CloudSecret, log logr.Logger) error {
+	var childSecret corev1.Secret
+	if err := r.Get(ctx, cloudSecret.GetChildSecretKey(), &childSecret); err == nil {
+		if err := r.Delete(ctx, &childSecret); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	childSecretDataKeys.DeleteLabelValues(cloudSecret.Namespace, cloudSecret.Name)
+
+	if !cloudSecret.Spec.PurgeRemote {
+		return nil
+	}
+
+	for k, entry := range cloudSecret.Spec.Data {
+		provider, ref, err := r.resolveProvider(entry.Ref)
+		if err != nil {
+			log.Error(err, "unable to resolve secret provider during purge", "secret_path", entry.Ref)
+			continue
+		}
+
+		purger, ok := provider.(providers.SecretPurger)
+		if !ok {
+			log.Info("provider does not support purging, leaving remote secret intact", "provider", provider.Name(), "key", k)
+			continue
+		}
+
+		if err := purger.PurgeSecret(ctx, ref); err != nil {
+			log.Error(err, "unable to purge remote secret", "provider", provider.Name(), "secret_path", entry.Ref)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitSchemeRef splits a Spec.Data value into its scheme and the
+// scheme-stripped remainder, e.g. "gcp://projects/..." -> ("gcp", "projects/...").
+// It splits on the literal "://" separator rather than using url.Parse,
+// since refs like the AWS provider's ARN form
+// ("aws://arn:aws:secretsmanager:us-east-1:123456789012:secret:foo") contain
+// colons url.Parse would otherwise misinterpret as a port. ref with no
+// "://" is returned verbatim as the remainder with an empty scheme.
+func splitSchemeRef(ref string) (scheme, remainder string, err error) {
+	scheme, remainder, ok := strings.Cut(ref, "://")
+	if !ok {
+		return "", ref, nil
+	}
+
+	return scheme, remainder, nil
+}
+
+// resolveProvider returns the SecretProvider registered for ref's URL
+// scheme along with the scheme-stripped remainder of ref.
+func (r *CloudSecretReconciler) resolveProvider(ref string) (providers.SecretProvider, string, error) {
+	scheme, remainder, err := splitSchemeRef(ref)
+	if err != nil {
+		return nil, "", err
+	}
+
+	provider, ok := r.Providers[scheme]
+	if !ok {
+		return nil, "", fmt.Errorf("no provider registered for scheme %q", scheme)
+	}
+
+	return provider, remainder, nil
+}
+
+// discoverSelected resolves a Spec.Selector against its provider, deriving
+// a child Secret key for each matched secret from selector.KeyTemplate. A
+// failure rendering the key or fetching one matched secret's payload does
+// not abort discovery: that secret is skipped and its error recorded, so a
+// single bad secret doesn't wipe out every other key this selector already
+// resolved. The caller still receives a non-nil error summarizing the
+// skipped secrets so it can flag the reconcile as failed.
+func (r *CloudSecretReconciler) discoverSelected(ctx context.Context, selector *secretsv1.SecretSelector) (map[string][]byte, error) {
+	provider, ok := r.Providers[selector.Provider]
+	if !ok {
+		return nil, fmt.Errorf("no provider registered for scheme %q", selector.Provider)
+	}
+
+	lister, ok := provider.(providers.SecretLister)
+	if !ok {
+		return nil, fmt.Errorf("provider %q does not support selector-based discovery", selector.Provider)
+	}
+
+	keyTmpl, err := template.New("selectorKey").Parse(selector.KeyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector keyTemplate: %w", err)
+	}
+
+	max := selector.MaxSecrets
+	if max <= 0 {
+		max = defaultMaxSelectorSecrets
+	}
+
+	found, err := lister.ListSecrets(ctx, selector.Filter, max)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string][]byte, len(found))
+	var failures []string
+	for _, secret := range found {
+		var key bytes.Buffer
+		if err := keyTmpl.Execute(&key, struct{ Labels map[string]string }{Labels: secret.Labels}); err != nil {
+			failures = append(failures, fmt.Sprintf("rendering keyTemplate for secret %q: %s", secret.Ref, err))
+			continue
+		}
+
+		payload, err := provider.AccessSecret(ctx, secret.Ref)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("accessing discovered secret %q: %s", secret.Ref, err))
+			continue
+		}
+
+		data[key.String()] = payload
+	}
+
+	if len(failures) > 0 {
+		return data, fmt.Errorf("%d of %d selector-discovered secrets failed: %s", len(failures), len(found), strings.Join(failures, "; "))
+	}
+
+	return data, nil
 }
 
 // +kubebuilder:rbac:groups=secrets.masonwr.dev,resources=cloudsecrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=secrets.masonwr.dev,resources=cloudsecrets/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 func (r *CloudSecretReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
 	log := r.Log.WithValues("cloudsecret", req.NamespacedName)
 
+	start := time.Now()
+	defer func() { reconcileDuration.WithLabelValues().Observe(time.Since(start).Seconds()) }()
+
 	// fetch cloud secret object
 	var cloudSecret secretsv1.CloudSecret
 	if err := r.Get(ctx, req.NamespacedName, &cloudSecret); err != nil {
@@ -53,12 +292,58 @@ func (r *CloudSecretReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	// handle deletion: run cleanup once, then drop the finalizer so the CR
+	// can actually be removed.
+	if !cloudSecret.DeletionTimestamp.IsZero() {
+		if containsString(cloudSecret.Finalizers, cloudSecretFinalizer) {
+			if err := r.finalizeCloudSecret(ctx, &cloudSecret, log); err != nil {
+				log.Error(err, "unable to finalize cloud secret")
+				return ctrl.Result{}, err
+			}
+
+			cloudSecret.Finalizers = removeString(cloudSecret.Finalizers, cloudSecretFinalizer)
+			if err := r.Update(ctx, &cloudSecret); err != nil {
+				log.Error(err, "unable to remove finalizer")
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !containsString(cloudSecret.Finalizers, cloudSecretFinalizer) {
+		cloudSecret.Finalizers = append(cloudSecret.Finalizers, cloudSecretFinalizer)
+		if err := r.Update(ctx, &cloudSecret); err != nil {
+			log.Error(err, "unable to add finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
 	recDelay := time.Duration(cloudSecret.Spec.SyncPeriod) * time.Second
 	result := ctrl.Result{RequeueAfter: recDelay}
 
-	// fetch associated k8s (child) secret, creating it if not found
+	// fetch associated k8s (child) secret, creating it if not found. If it's
+	// a stale tombstone (DeletionTimestamp set, finalizer stuck), its
+	// finalizers are cleared and the reconcile is requeued, so a later pass
+	// finds it gone and recreates it.
 	var childSecret corev1.Secret
-	if err := r.Get(ctx, cloudSecret.GetChildSecretKey(), &childSecret); err != nil {
+	err := r.Get(ctx, cloudSecret.GetChildSecretKey(), &childSecret)
+	switch {
+	case err != nil && !apierrors.IsNotFound(err):
+		log.Error(err, "unable to fetch child secret")
+		return ctrl.Result{}, err
+
+	case err == nil && !childSecret.DeletionTimestamp.IsZero():
+		if len(childSecret.Finalizers) > 0 {
+			log.Info("clearing finalizers on stuck child secret")
+			childSecret.Finalizers = nil
+			if err := r.Update(ctx, &childSecret); err != nil {
+				log.Error(err, "unable to clear finalizers on child secret")
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{RequeueAfter: defaultRetryTime}, nil
+
+	case err != nil:
 		log.Info("creating child secret")
 		childSecret = cloudSecret.InitChildSecret()
 		if err := r.Create(ctx, &childSecret); err != nil {
@@ -67,24 +352,94 @@ func (r *CloudSecretReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		}
 	}
 
+	hadPriorData := len(childSecret.Data) > 0
+
 	// nothing left todo
-	if len(cloudSecret.Spec.Data) == 0 {
+	if len(cloudSecret.Spec.Data) == 0 && cloudSecret.Spec.Selector == nil {
 		log.Info("empty cloud secret")
 		return result, nil
 	}
 
 	// init and copy data to child k8s secret
 	getSecretFail := false
+	failReason := ""
 	childSecret.Data = make(map[string][]byte)
-	for k, v := range cloudSecret.Spec.Data {
-		access, err := r.GcpSecrets.AccessSecretVersion(ctx, &secrets.AccessSecretVersionRequest{Name: v})
+
+	if selector := cloudSecret.Spec.Selector; selector != nil {
+		// discoverSelected returns every secret it did manage to resolve
+		// even when err is non-nil, so a single bad secret doesn't cost us
+		// the rest of the selector's matches.
+		discovered, err := r.discoverSelected(ctx, selector)
 		if err != nil {
-			log.Error(err, "unable to access secret", "secret_path", v)
-			getSecretFail = true
-			continue
+			log.Error(err, "selector discovery had failures")
+			reason := classifyFetchError(err)
+			getSecretFail, failReason = true, reason
+			fetchTotal.WithLabelValues(selector.Provider, "failure").Inc()
+			r.Recorder.Eventf(&cloudSecret, corev1.EventTypeWarning, reason, "selector discovery failed: %s", err)
+		} else {
+			fetchTotal.WithLabelValues(selector.Provider, "success").Inc()
 		}
+		for k, v := range discovered {
+			childSecret.Data[k] = v
+		}
+	}
 
-		childSecret.Data[k] = access.Payload.GetData()
+	// Spec.Data keys are resolved in two deterministic (lexically sorted)
+	// passes rather than in Go's randomized map iteration order: every
+	// non-Template entry first, then every Template entry. A Template
+	// referencing another key via `.Secrets` would otherwise see that key
+	// populated or not depending on which order the map iteration happened
+	// to land on for that reconcile.
+	keys := make([]string, 0, len(cloudSecret.Spec.Data))
+	for k := range cloudSecret.Spec.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	resolve := func(k string) {
+		entry := cloudSecret.Spec.Data[k]
+
+		provider, ref, err := r.resolveProvider(entry.Ref)
+		if err != nil {
+			log.Error(err, "unable to resolve secret provider", "secret_path", entry.Ref)
+			getSecretFail, failReason = true, secretsv1.ReasonProviderUnavailable
+			r.Recorder.Eventf(&cloudSecret, corev1.EventTypeWarning, secretsv1.ReasonProviderUnavailable, "%s: %s", entry.Ref, err)
+			return
+		}
+
+		raw, err := provider.AccessSecret(ctx, ref)
+		if err != nil {
+			log.Error(err, "unable to access secret", "provider", provider.Name(), "secret_path", entry.Ref)
+			reason := classifyFetchError(err)
+			getSecretFail, failReason = true, reason
+			fetchTotal.WithLabelValues(provider.Name(), "failure").Inc()
+			r.Recorder.Eventf(&cloudSecret, corev1.EventTypeWarning, reason, "%s: %s", entry.Ref, err)
+			return
+		}
+
+		data, err := transformPayload(raw, entry, childSecret.Data)
+		if err != nil {
+			log.Error(err, "unable to transform secret payload", "key", k, "secret_path", entry.Ref)
+			getSecretFail, failReason = true, secretsv1.ReasonProviderUnavailable
+			r.Recorder.Eventf(&cloudSecret, corev1.EventTypeWarning, secretsv1.ReasonProviderUnavailable, "transforming %s: %s", k, err)
+			return
+		}
+
+		fetchTotal.WithLabelValues(provider.Name(), "success").Inc()
+		childSecret.Data[k] = data
+	}
+
+	var templated []string
+	for _, k := range keys {
+		entry := cloudSecret.Spec.Data[k]
+		if entry.Template != "" {
+			templated = append(templated, k)
+			continue
+		}
+		resolve(k)
+	}
+	for _, k := range templated {
+		resolve(k)
 	}
 
 	// if we failed to fetch a secret we retry by re-queuing.
@@ -93,12 +448,28 @@ func (r *CloudSecretReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		result.RequeueAfter = defaultRetryTime
 	}
 
-	// if no secrets were resolved, we delete the child secret
-	// in an attempt to fail with more noise.
+	childSecretDataKeys.WithLabelValues(cloudSecret.Namespace, cloudSecret.Name).Set(float64(len(childSecret.Data)))
+
+	// if no secrets were resolved, we delete the child secret in an attempt
+	// to fail with more noise -- unless it previously held data, in which
+	// case deleting it would destroy a consumer's last-known-good secret
+	// during a transient outage. Spec.DeleteOnEmpty opts back into the old
+	// behavior.
 	if len(childSecret.Data) == 0 {
-		if err := r.Delete(ctx, &childSecret); err != nil {
-			log.Error(err, "unable to delete child secret")
-			return ctrl.Result{}, err
+		if !getSecretFail {
+			failReason = secretsv1.ReasonEmpty
+		}
+		r.setConditions(&cloudSecret, false, failReason, false)
+		_ = r.Status().Update(ctx, &cloudSecret)
+
+		if !hadPriorData || cloudSecret.Spec.DeleteOnEmpty {
+			if err := r.Delete(ctx, &childSecret); err != nil {
+				log.Error(err, "unable to delete child secret")
+				return ctrl.Result{}, err
+			}
+		} else {
+			log.Info("all secrets empty, preserving child secret with prior data", "reason", failReason)
+			r.Recorder.Event(&cloudSecret, corev1.EventTypeWarning, secretsv1.ReasonEmpty, "resolved zero keys; preserving previously synced child secret")
 		}
 
 		return result, nil
@@ -107,14 +478,63 @@ func (r *CloudSecretReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 	log.Info("updating child secret")
 	if err := r.Update(ctx, &childSecret); err != nil {
 		log.Error(err, "unable to update child secret")
+		r.setConditions(&cloudSecret, !getSecretFail, failReason, false)
+		_ = r.Status().Update(ctx, &cloudSecret)
 		return ctrl.Result{}, err
 	}
 
+	r.setConditions(&cloudSecret, !getSecretFail, failReason, true)
+	if err := r.Status().Update(ctx, &cloudSecret); err != nil {
+		log.Error(err, "unable to update cloud secret status")
+	}
+
 	return result, nil
 }
 
+// setConditions updates Ready/SecretsFetched/ChildSecretSynced based on the
+// outcome of the reconcile that just ran.
+func (r *CloudSecretReconciler) setConditions(cloudSecret *secretsv1.CloudSecret, secretsFetched bool, failReason string, childSynced bool) {
+	now := metav1.Now()
+
+	fetchedStatus, fetchedReason := secretsv1.ConditionTrue, secretsv1.ReasonSynced
+	if !secretsFetched {
+		fetchedStatus, fetchedReason = secretsv1.ConditionFalse, failReason
+	}
+	cloudSecret.Status.SetCondition(secretsv1.ConditionSecretsFetched, fetchedStatus, fetchedReason, "", now)
+
+	syncedStatus, syncedReason := secretsv1.ConditionFalse, failReason
+	if childSynced {
+		syncedStatus, syncedReason = secretsv1.ConditionTrue, secretsv1.ReasonSynced
+	}
+	cloudSecret.Status.SetCondition(secretsv1.ConditionChildSecretSynced, syncedStatus, syncedReason, "", now)
+
+	readyStatus := secretsv1.ConditionTrue
+	readyReason := secretsv1.ReasonSynced
+	if !secretsFetched || !childSynced {
+		readyStatus, readyReason = secretsv1.ConditionFalse, failReason
+	}
+	cloudSecret.Status.SetCondition(secretsv1.ConditionReady, readyStatus, readyReason, "", now)
+}
+
 func (r *CloudSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	remoteChanges := make(chan event.GenericEvent)
+
+	if err := mgr.Add(&RemotePoller{
+		Reader:   mgr.GetClient(),
+		Log:      r.Log.WithName("remote-poller"),
+		Registry: r.Providers,
+		Interval: r.RemotePollInterval,
+		Events:   remoteChanges,
+	}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&secretsv1.CloudSecret{}).
+		Owns(&corev1.Secret{}).
+		Watches(&source.Channel{Source: remoteChanges}, &handler.EnqueueRequestForObject{}).
+		WithOptions(controller.Options{
+			RateLimiter: workqueue.NewItemFastSlowRateLimiter(1*time.Second, 60*time.Second, 5),
+		}).
 		Complete(r)
 }
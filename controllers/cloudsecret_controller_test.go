@@ -0,0 +1,369 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	vaultapi "github.com/hashicorp/vault/api"
+	secretsv1 "github.com/masonwr/CloudSecret/api/v1"
+	"github.com/masonwr/CloudSecret/providers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSplitSchemeRef(t *testing.T) {
+	tests := []struct {
+		name          string
+		ref           string
+		wantScheme    string
+		wantRemainder string
+		wantErr       bool
+	}{
+		{
+			name:          "gcp ref",
+			ref:           "gcp://projects/my-proj/secrets/my-secret/versions/latest",
+			wantScheme:    "gcp",
+			wantRemainder: "projects/my-proj/secrets/my-secret/versions/latest",
+		},
+		{
+			name:          "vault ref with hash fragment in path",
+			ref:           "vault://secret/data/foo#password",
+			wantScheme:    "vault",
+			wantRemainder: "secret/data/foo#password",
+		},
+		{
+			// the colons in the ARN must not be mistaken for a URL port.
+			name:          "aws ref with an ARN containing colons",
+			ref:           "aws://arn:aws:secretsmanager:us-east-1:123456789012:secret:foo",
+			wantScheme:    "aws",
+			wantRemainder: "arn:aws:secretsmanager:us-east-1:123456789012:secret:foo",
+		},
+		{
+			// a ref with no "://" separator is returned verbatim as the
+			// remainder with an empty scheme.
+			name:          "no scheme",
+			ref:           "my-secret",
+			wantScheme:    "",
+			wantRemainder: "my-secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme, remainder, err := splitSchemeRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitSchemeRef(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			}
+			if scheme != tt.wantScheme {
+				t.Errorf("splitSchemeRef(%q) scheme = %q, want %q", tt.ref, scheme, tt.wantScheme)
+			}
+			if remainder != tt.wantRemainder {
+				t.Errorf("splitSchemeRef(%q) remainder = %q, want %q", tt.ref, remainder, tt.wantRemainder)
+			}
+		})
+	}
+}
+
+type fakeProvider struct {
+	name string
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) AccessSecret(ctx context.Context, ref string) ([]byte, error) {
+	return []byte(ref), nil
+}
+
+func TestResolveProvider(t *testing.T) {
+	r := &CloudSecretReconciler{
+		Providers: providers.Registry{
+			"gcp": &fakeProvider{name: "gcp"},
+		},
+	}
+
+	provider, remainder, err := r.resolveProvider("gcp://projects/my-proj/secrets/my-secret")
+	if err != nil {
+		t.Fatalf("resolveProvider returned error: %v", err)
+	}
+	if provider.Name() != "gcp" {
+		t.Errorf("resolveProvider provider = %q, want %q", provider.Name(), "gcp")
+	}
+	if remainder != "projects/my-proj/secrets/my-secret" {
+		t.Errorf("resolveProvider remainder = %q, want %q", remainder, "projects/my-proj/secrets/my-secret")
+	}
+
+	if _, _, err := r.resolveProvider("aws://some-secret"); err == nil {
+		t.Error("resolveProvider with unregistered scheme: expected error, got nil")
+	}
+}
+
+// fakeLister is a fakeProvider that also implements providers.SecretLister,
+// returning a fixed set of secrets regardless of filter. AccessSecret fails
+// for any ref listed in failRefs, to exercise discoverSelected's per-secret
+// error handling.
+type fakeLister struct {
+	fakeProvider
+	secrets  []providers.ListedSecret
+	failRefs map[string]bool
+}
+
+func (p *fakeLister) ListSecrets(ctx context.Context, filter string, max int64) ([]providers.ListedSecret, error) {
+	if max <= 0 || max > int64(len(p.secrets)) {
+		max = int64(len(p.secrets))
+	}
+	return p.secrets[:max], nil
+}
+
+func (p *fakeLister) AccessSecret(ctx context.Context, ref string) ([]byte, error) {
+	if p.failRefs[ref] {
+		return nil, fmt.Errorf("simulated failure accessing %q", ref)
+	}
+	return p.fakeProvider.AccessSecret(ctx, ref)
+}
+
+func TestDiscoverSelected(t *testing.T) {
+	lister := &fakeLister{
+		fakeProvider: fakeProvider{name: "gcp"},
+		secrets: []providers.ListedSecret{
+			{Ref: "secret/one", Labels: map[string]string{"k8sKey": "one"}},
+			{Ref: "secret/two", Labels: map[string]string{"k8sKey": "two"}},
+		},
+	}
+	r := &CloudSecretReconciler{Providers: providers.Registry{"gcp": lister}}
+
+	data, err := r.discoverSelected(context.Background(), &secretsv1.SecretSelector{
+		Provider:    "gcp",
+		Filter:      "env=prod",
+		KeyTemplate: "{{ .Labels.k8sKey }}",
+	})
+	if err != nil {
+		t.Fatalf("discoverSelected returned error: %v", err)
+	}
+	if string(data["one"]) != "secret/one" || string(data["two"]) != "secret/two" {
+		t.Errorf("discoverSelected data = %v, want keys \"one\"/\"two\" holding their refs", data)
+	}
+}
+
+func TestDiscoverSelectedMaxSecretsCap(t *testing.T) {
+	lister := &fakeLister{
+		fakeProvider: fakeProvider{name: "gcp"},
+		secrets: []providers.ListedSecret{
+			{Ref: "secret/one", Labels: map[string]string{"k8sKey": "one"}},
+			{Ref: "secret/two", Labels: map[string]string{"k8sKey": "two"}},
+			{Ref: "secret/three", Labels: map[string]string{"k8sKey": "three"}},
+		},
+	}
+	r := &CloudSecretReconciler{Providers: providers.Registry{"gcp": lister}}
+
+	data, err := r.discoverSelected(context.Background(), &secretsv1.SecretSelector{
+		Provider:    "gcp",
+		KeyTemplate: "{{ .Labels.k8sKey }}",
+		MaxSecrets:  1,
+	})
+	if err != nil {
+		t.Fatalf("discoverSelected returned error: %v", err)
+	}
+	if len(data) != 1 {
+		t.Errorf("discoverSelected with MaxSecrets=1 returned %d keys, want 1", len(data))
+	}
+}
+
+// TestDiscoverSelectedPartialFailurePreservesSuccesses guards against the
+// bug where a single failed secret discarded every other key the selector
+// had already resolved.
+func TestDiscoverSelectedPartialFailurePreservesSuccesses(t *testing.T) {
+	lister := &fakeLister{
+		fakeProvider: fakeProvider{name: "gcp"},
+		secrets: []providers.ListedSecret{
+			{Ref: "secret/one", Labels: map[string]string{"k8sKey": "one"}},
+			{Ref: "secret/two", Labels: map[string]string{"k8sKey": "two"}},
+		},
+		failRefs: map[string]bool{"secret/two": true},
+	}
+	r := &CloudSecretReconciler{Providers: providers.Registry{"gcp": lister}}
+
+	data, err := r.discoverSelected(context.Background(), &secretsv1.SecretSelector{
+		Provider:    "gcp",
+		KeyTemplate: "{{ .Labels.k8sKey }}",
+	})
+	if err == nil {
+		t.Fatal("discoverSelected with one failing secret: expected a non-nil error, got nil")
+	}
+	if string(data["one"]) != "secret/one" {
+		t.Errorf("discoverSelected data = %v, want the successfully-fetched key \"one\" preserved", data)
+	}
+	if _, ok := data["two"]; ok {
+		t.Errorf("discoverSelected data = %v, want no entry for the failed key \"two\"", data)
+	}
+}
+
+func TestDiscoverSelectedUnknownProvider(t *testing.T) {
+	r := &CloudSecretReconciler{Providers: providers.Registry{}}
+
+	if _, err := r.discoverSelected(context.Background(), &secretsv1.SecretSelector{Provider: "gcp"}); err == nil {
+		t.Error("discoverSelected with unregistered provider: expected error, got nil")
+	}
+}
+
+func TestDiscoverSelectedProviderWithoutLister(t *testing.T) {
+	r := &CloudSecretReconciler{Providers: providers.Registry{"gcp": &fakeProvider{name: "gcp"}}}
+
+	if _, err := r.discoverSelected(context.Background(), &secretsv1.SecretSelector{Provider: "gcp"}); err == nil {
+		t.Error("discoverSelected with non-lister provider: expected error, got nil")
+	}
+}
+
+func TestSetConditions(t *testing.T) {
+	r := &CloudSecretReconciler{}
+
+	var cloudSecret secretsv1.CloudSecret
+	r.setConditions(&cloudSecret, true, "", true)
+
+	want := map[secretsv1.ConditionType]secretsv1.ConditionStatus{
+		secretsv1.ConditionReady:             secretsv1.ConditionTrue,
+		secretsv1.ConditionSecretsFetched:    secretsv1.ConditionTrue,
+		secretsv1.ConditionChildSecretSynced: secretsv1.ConditionTrue,
+	}
+	for _, c := range cloudSecret.Status.Conditions {
+		if c.Status != want[c.Type] {
+			t.Errorf("condition %s = %s, want %s", c.Type, c.Status, want[c.Type])
+		}
+		if c.Reason != secretsv1.ReasonSynced {
+			t.Errorf("condition %s reason = %s, want %s", c.Type, c.Reason, secretsv1.ReasonSynced)
+		}
+	}
+
+	r.setConditions(&cloudSecret, false, secretsv1.ReasonSecretNotFound, false)
+
+	for _, c := range cloudSecret.Status.Conditions {
+		switch c.Type {
+		case secretsv1.ConditionSecretsFetched, secretsv1.ConditionReady:
+			if c.Status != secretsv1.ConditionFalse || c.Reason != secretsv1.ReasonSecretNotFound {
+				t.Errorf("condition %s = %s/%s, want False/%s", c.Type, c.Status, c.Reason, secretsv1.ReasonSecretNotFound)
+			}
+		case secretsv1.ConditionChildSecretSynced:
+			if c.Status != secretsv1.ConditionFalse {
+				t.Errorf("condition %s = %s, want False", c.Type, c.Status)
+			}
+		}
+	}
+}
+
+func TestSetConditionsOnlyBumpsTransitionTimeOnChange(t *testing.T) {
+	r := &CloudSecretReconciler{}
+
+	var cloudSecret secretsv1.CloudSecret
+	r.setConditions(&cloudSecret, true, "", true)
+
+	var before metav1.Time
+	for _, c := range cloudSecret.Status.Conditions {
+		if c.Type == secretsv1.ConditionReady {
+			before = c.LastTransitionTime
+		}
+	}
+
+	r.setConditions(&cloudSecret, true, "", true)
+
+	for _, c := range cloudSecret.Status.Conditions {
+		if c.Type == secretsv1.ConditionReady && !c.LastTransitionTime.Equal(&before) {
+			t.Errorf("LastTransitionTime changed on a no-op status update: %v -> %v", before, c.LastTransitionTime)
+		}
+	}
+}
+
+func TestClassifyFetchErrorDefaultsToProviderUnavailable(t *testing.T) {
+	if got := classifyFetchError(errors.New("boom")); got != secretsv1.ReasonProviderUnavailable {
+		t.Errorf("classifyFetchError(generic error) = %s, want %s", got, secretsv1.ReasonProviderUnavailable)
+	}
+}
+
+func TestClassifyFetchErrorAWS(t *testing.T) {
+	if got := classifyFetchError(&smtypes.ResourceNotFoundException{}); got != secretsv1.ReasonSecretNotFound {
+		t.Errorf("classifyFetchError(aws not found) = %s, want %s", got, secretsv1.ReasonSecretNotFound)
+	}
+}
+
+func TestClassifyFetchErrorAzure(t *testing.T) {
+	if got := classifyFetchError(&azcore.ResponseError{StatusCode: http.StatusForbidden}); got != secretsv1.ReasonPermissionDenied {
+		t.Errorf("classifyFetchError(azure forbidden) = %s, want %s", got, secretsv1.ReasonPermissionDenied)
+	}
+	if got := classifyFetchError(&azcore.ResponseError{StatusCode: http.StatusNotFound}); got != secretsv1.ReasonSecretNotFound {
+		t.Errorf("classifyFetchError(azure not found) = %s, want %s", got, secretsv1.ReasonSecretNotFound)
+	}
+}
+
+func TestClassifyFetchErrorVault(t *testing.T) {
+	if got := classifyFetchError(&vaultapi.ResponseError{StatusCode: http.StatusNotFound}); got != secretsv1.ReasonSecretNotFound {
+		t.Errorf("classifyFetchError(vault not found) = %s, want %s", got, secretsv1.ReasonSecretNotFound)
+	}
+}
+
+func TestContainsString(t *testing.T) {
+	slice := []string{"a", "b", "c"}
+
+	if !containsString(slice, "b") {
+		t.Error("containsString(_, \"b\") = false, want true")
+	}
+	if containsString(slice, "z") {
+		t.Error("containsString(_, \"z\") = true, want false")
+	}
+	if containsString(nil, "a") {
+		t.Error("containsString(nil, \"a\") = true, want false")
+	}
+}
+
+func TestRemoveString(t *testing.T) {
+	got := removeString([]string{"a", "b", "a", "c"}, "a")
+	want := []string{"b", "c"}
+
+	if len(got) != len(want) {
+		t.Fatalf("removeString(%v) = %v, want %v", []string{"a", "b", "a", "c"}, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("removeString(%v) = %v, want %v", []string{"a", "b", "a", "c"}, got, want)
+		}
+	}
+}
+
+func TestFinalizeCloudSecretSkipsPurgeWhenProviderLacksSupport(t *testing.T) {
+	cloudSecret := &secretsv1.CloudSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec: secretsv1.CloudSecretSpec{
+			PurgeRemote: true,
+			Data: map[string]secretsv1.SecretDataEntry{
+				"password": {Ref: "gcp://projects/my-proj/secrets/db-password/versions/latest"},
+			},
+		},
+	}
+
+	r := &CloudSecretReconciler{
+		Client:    fake.NewFakeClient(),
+		Providers: providers.Registry{"gcp": &fakeProvider{name: "gcp"}},
+	}
+
+	if err := r.finalizeCloudSecret(context.Background(), cloudSecret, ctrl.Log); err != nil {
+		t.Fatalf("finalizeCloudSecret returned error: %v", err)
+	}
+}
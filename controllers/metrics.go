@@ -0,0 +1,43 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	fetchTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cloudsecret_fetch_total",
+		Help: "Total number of secret fetches attempted, by provider and result.",
+	}, []string{"provider", "result"})
+
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cloudsecret_reconcile_duration_seconds",
+		Help:    "Duration of CloudSecret reconcile calls.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{})
+
+	childSecretDataKeys = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cloudsecret_child_secret_data_keys",
+		Help: "Number of keys currently present in a CloudSecret's child Secret.",
+	}, []string{"namespace", "name"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(fetchTotal, reconcileDuration, childSecretDataKeys)
+}
@@ -0,0 +1,137 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	secretsv1 "github.com/masonwr/CloudSecret/api/v1"
+	"github.com/masonwr/CloudSecret/providers"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// defaultRemotePollInterval is how often RemotePoller checks referenced
+// secrets for upstream rotation when CloudSecretReconciler.RemotePollInterval
+// is unset.
+const defaultRemotePollInterval = 1 * time.Minute
+
+// RemotePoller periodically checks every CloudSecret's Spec.Data refs
+// against providers.VersionWatcher and enqueues a reconcile for any whose
+// upstream version changed since the last poll. It closes the gap left by
+// RequeueAfter alone: without it, a secret rotated in the backend sits
+// stale in the child Secret until the next sync period elapses.
+type RemotePoller struct {
+	client.Reader
+	Log      logr.Logger
+	Registry providers.Registry
+	Interval time.Duration
+	Events   chan<- event.GenericEvent
+
+	mu       sync.Mutex
+	lastSeen map[string]string
+}
+
+// Start implements manager.Runnable, polling until stop is closed.
+func (p *RemotePoller) Start(stop <-chan struct{}) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = defaultRemotePollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			p.poll(context.Background(), stop)
+		}
+	}
+}
+
+func (p *RemotePoller) poll(ctx context.Context, stop <-chan struct{}) {
+	var list secretsv1.CloudSecretList
+	if err := p.List(ctx, &list); err != nil {
+		p.Log.Error(err, "unable to list cloud secrets for remote poll")
+		return
+	}
+
+	p.mu.Lock()
+	if p.lastSeen == nil {
+		p.lastSeen = map[string]string{}
+	}
+	p.mu.Unlock()
+
+	for i := range list.Items {
+		cloudSecret := list.Items[i]
+		for _, entry := range cloudSecret.Spec.Data {
+			p.checkRef(cloudSecret, entry.Ref, stop)
+		}
+	}
+}
+
+// checkRef polls ref's upstream version and, if it changed since the last
+// poll, enqueues a reconcile by sending on p.Events. The send is guarded by
+// stop so a slow/blocked consumer can't wedge the poller goroutine forever;
+// if stop fires first the event is dropped and picked up on SyncPeriod
+// instead.
+func (p *RemotePoller) checkRef(cloudSecret secretsv1.CloudSecret, ref string, stop <-chan struct{}) {
+	scheme, remainder, err := splitSchemeRef(ref)
+	if err != nil {
+		return
+	}
+
+	provider, ok := p.Registry[scheme]
+	if !ok {
+		return
+	}
+
+	watcher, ok := provider.(providers.VersionWatcher)
+	if !ok {
+		return
+	}
+
+	version, err := watcher.LatestVersion(context.Background(), remainder)
+	if err != nil {
+		p.Log.Error(err, "unable to poll remote secret version", "provider", scheme, "ref", ref)
+		return
+	}
+
+	key := cloudSecret.Namespace + "/" + cloudSecret.Name + "#" + ref
+
+	p.mu.Lock()
+	if p.lastSeen == nil {
+		p.lastSeen = map[string]string{}
+	}
+	last, seen := p.lastSeen[key]
+	p.lastSeen[key] = version
+	p.mu.Unlock()
+
+	if seen && last != version {
+		p.Log.Info("detected upstream secret rotation, enqueuing reconcile", "namespace", cloudSecret.Namespace, "name", cloudSecret.Name)
+		select {
+		case p.Events <- event.GenericEvent{Object: &cloudSecret}:
+		case <-stop:
+			p.Log.Info("poller stopping, dropping pending rotation event", "namespace", cloudSecret.Namespace, "name", cloudSecret.Name)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	secretsv1 "github.com/masonwr/CloudSecret/api/v1"
+	"github.com/masonwr/CloudSecret/providers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// fakeWatcher is a fakeProvider that also implements providers.VersionWatcher,
+// returning versions from a fixed queue on each call.
+type fakeWatcher struct {
+	fakeProvider
+	versions []string
+	calls    int
+}
+
+func (p *fakeWatcher) LatestVersion(ctx context.Context, ref string) (string, error) {
+	v := p.versions[p.calls]
+	if p.calls < len(p.versions)-1 {
+		p.calls++
+	}
+	return v, nil
+}
+
+func newTestCloudSecret(ref string) secretsv1.CloudSecret {
+	return secretsv1.CloudSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec: secretsv1.CloudSecretSpec{
+			Data: map[string]secretsv1.SecretDataEntry{"password": {Ref: ref}},
+		},
+	}
+}
+
+func TestCheckRefEnqueuesOnRotation(t *testing.T) {
+	watcher := &fakeWatcher{fakeProvider: fakeProvider{name: "gcp"}, versions: []string{"v1", "v2"}}
+	events := make(chan event.GenericEvent, 1)
+	p := &RemotePoller{Registry: providers.Registry{"gcp": watcher}, Events: events}
+	stop := make(chan struct{})
+
+	cloudSecret := newTestCloudSecret("gcp://projects/my-proj/secrets/db-password/versions/latest")
+
+	// first poll only observes the version, nothing to enqueue yet.
+	p.checkRef(cloudSecret, cloudSecret.Spec.Data["password"].Ref, stop)
+	select {
+	case <-events:
+		t.Fatal("checkRef enqueued an event on the first observation, want none")
+	default:
+	}
+
+	// second poll sees the version changed and enqueues a reconcile.
+	p.checkRef(cloudSecret, cloudSecret.Spec.Data["password"].Ref, stop)
+	select {
+	case <-events:
+	default:
+		t.Fatal("checkRef did not enqueue an event after a version change")
+	}
+}
+
+func TestCheckRefDoesNotBlockForeverWhenStopped(t *testing.T) {
+	watcher := &fakeWatcher{fakeProvider: fakeProvider{name: "gcp"}, versions: []string{"v1", "v2"}}
+	// unbuffered and never drained: a blocking send would hang this test.
+	events := make(chan event.GenericEvent)
+	p := &RemotePoller{Registry: providers.Registry{"gcp": watcher}, Events: events}
+	stop := make(chan struct{})
+
+	cloudSecret := newTestCloudSecret("gcp://projects/my-proj/secrets/db-password/versions/latest")
+	ref := cloudSecret.Spec.Data["password"].Ref
+
+	p.checkRef(cloudSecret, ref, stop)
+	close(stop)
+
+	done := make(chan struct{})
+	go func() {
+		p.checkRef(cloudSecret, ref, stop)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("checkRef blocked on an unread Events channel after stop was closed")
+	}
+}
+
+func TestCheckRefUnknownSchemeIsANoop(t *testing.T) {
+	p := &RemotePoller{Registry: providers.Registry{}, Events: make(chan event.GenericEvent)}
+	p.checkRef(newTestCloudSecret("aws://some-secret"), "aws://some-secret", nil)
+}
@@ -0,0 +1,129 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	secretsv1 "github.com/masonwr/CloudSecret/api/v1"
+)
+
+// transformPayload applies entry's Decode/JSONPath/Template chain, in that
+// order, to a secret's raw payload. resolved holds every child Secret key
+// already populated earlier in this reconcile, exposed to Template as
+// `.Secrets` so one key can be composed from others. The caller is
+// responsible for resolving non-Template entries before Template entries
+// (see Reconcile) so that `.Secrets` lookups are deterministic rather than
+// depending on map iteration order.
+func transformPayload(raw []byte, entry secretsv1.SecretDataEntry, resolved map[string][]byte) ([]byte, error) {
+	data := raw
+
+	if entry.Decode == "base64" {
+		decoded := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+		n, err := base64.StdEncoding.Decode(decoded, data)
+		if err != nil {
+			return nil, fmt.Errorf("base64 decoding payload: %w", err)
+		}
+		data = decoded[:n]
+	}
+
+	if entry.JSONPath != "" {
+		extracted, err := extractJSONPath(data, entry.JSONPath)
+		if err != nil {
+			return nil, fmt.Errorf("extracting jsonPath %q: %w", entry.JSONPath, err)
+		}
+		data = extracted
+	}
+
+	if entry.Template != "" {
+		rendered, err := renderTemplate(entry.Template, data, resolved)
+		if err != nil {
+			return nil, fmt.Errorf("rendering template: %w", err)
+		}
+		data = rendered
+	}
+
+	return data, nil
+}
+
+// extractJSONPath parses data as a JSON object and walks a dotted field
+// path (e.g. "database.password") down nested objects, returning the leaf
+// value as a string.
+func extractJSONPath(data []byte, path string) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("payload is not valid JSON: %w", err)
+	}
+
+	cur := doc
+	for _, field := range strings.Split(path, ".") {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot descend into %q: not a JSON object", field)
+		}
+
+		cur, ok = obj[field]
+		if !ok {
+			return nil, fmt.Errorf("field %q not found", field)
+		}
+	}
+
+	switch v := cur.(type) {
+	case string:
+		return []byte(v), nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return encoded, nil
+	}
+}
+
+// renderTemplate executes tmplStr with `.Payload` set to payload and
+// `.Secrets` set to every already-resolved child Secret key (as strings).
+// Referencing a `.Secrets` key that hasn't resolved yet is a hard error
+// (missingkey=error) rather than the text/template default of silently
+// substituting the literal string "<no value>".
+func renderTemplate(tmplStr string, payload []byte, resolved map[string][]byte) ([]byte, error) {
+	tmpl, err := template.New("dataEntry").Option("missingkey=error").Parse(tmplStr)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string]string, len(resolved))
+	for k, v := range resolved {
+		secrets[k] = string(v)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, struct {
+		Payload string
+		Secrets map[string]string
+	}{
+		Payload: string(payload),
+		Secrets: secrets,
+	}); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
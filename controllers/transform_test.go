@@ -0,0 +1,111 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	secretsv1 "github.com/masonwr/CloudSecret/api/v1"
+)
+
+func TestTransformPayloadDecode(t *testing.T) {
+	// base64("hunter2") = "aHVudGVyMg=="
+	data, err := transformPayload([]byte("aHVudGVyMg=="), secretsv1.SecretDataEntry{Decode: "base64"}, nil)
+	if err != nil {
+		t.Fatalf("transformPayload returned error: %v", err)
+	}
+	if string(data) != "hunter2" {
+		t.Errorf("transformPayload decoded = %q, want %q", data, "hunter2")
+	}
+}
+
+func TestTransformPayloadJSONPath(t *testing.T) {
+	data, err := transformPayload([]byte(`{"database":{"password":"hunter2"}}`), secretsv1.SecretDataEntry{JSONPath: "database.password"}, nil)
+	if err != nil {
+		t.Fatalf("transformPayload returned error: %v", err)
+	}
+	if string(data) != "hunter2" {
+		t.Errorf("transformPayload extracted = %q, want %q", data, "hunter2")
+	}
+}
+
+func TestTransformPayloadTemplateComposesFromResolved(t *testing.T) {
+	resolved := map[string][]byte{"username": []byte("admin")}
+
+	data, err := transformPayload([]byte("hunter2"), secretsv1.SecretDataEntry{
+		Template: "postgres://{{ .Secrets.username }}:{{ .Payload }}@db",
+	}, resolved)
+	if err != nil {
+		t.Fatalf("transformPayload returned error: %v", err)
+	}
+
+	want := "postgres://admin:hunter2@db"
+	if string(data) != want {
+		t.Errorf("transformPayload rendered = %q, want %q", data, want)
+	}
+}
+
+// TestTransformPayloadTemplateMissingSecretIsAnError guards against the bug
+// where referencing a `.Secrets` key that hasn't resolved yet silently
+// rendered the literal string "<no value>" instead of failing the
+// reconcile. Reconcile is responsible for only calling transformPayload for
+// a Template entry once every non-Template key has resolved; this pins the
+// lower-level contract that a still-missing key is a hard error.
+func TestTransformPayloadTemplateMissingSecretIsAnError(t *testing.T) {
+	_, err := transformPayload([]byte("hunter2"), secretsv1.SecretDataEntry{
+		Template: "postgres://{{ .Secrets.username }}:{{ .Payload }}@db",
+	}, map[string][]byte{})
+	if err == nil {
+		t.Fatal("transformPayload with an unresolved .Secrets key: expected error, got nil")
+	}
+	if strings.Contains(err.Error(), "no value") {
+		t.Errorf("transformPayload error = %v, want it to reject rather than substitute <no value>", err)
+	}
+}
+
+func TestTransformPayloadChain(t *testing.T) {
+	raw := []byte(`eyJkYXRhYmFzZSI6eyJwYXNzd29yZCI6Imh1bnRlcjIifX0=`)
+
+	data, err := transformPayload(raw, secretsv1.SecretDataEntry{
+		Decode:   "base64",
+		JSONPath: "database.password",
+	}, nil)
+	if err != nil {
+		t.Fatalf("transformPayload returned error: %v", err)
+	}
+	if string(data) != "hunter2" {
+		t.Errorf("transformPayload = %q, want %q", data, "hunter2")
+	}
+}
+
+func TestExtractJSONPathNotAnObject(t *testing.T) {
+	if _, err := extractJSONPath([]byte(`"just a string"`), "database.password"); err == nil {
+		t.Error("extractJSONPath descending into a non-object: expected error, got nil")
+	}
+}
+
+func TestExtractJSONPathFieldNotFound(t *testing.T) {
+	if _, err := extractJSONPath([]byte(`{"database":{}}`), "database.password"); err == nil {
+		t.Error("extractJSONPath with a missing field: expected error, got nil")
+	}
+}
+
+func TestExtractJSONPathInvalidJSON(t *testing.T) {
+	if _, err := extractJSONPath([]byte(`not json`), "database.password"); err == nil {
+		t.Error("extractJSONPath on invalid JSON: expected error, got nil")
+	}
+}
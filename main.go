@@ -0,0 +1,140 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+	"time"
+
+	secretsv1 "github.com/masonwr/CloudSecret/api/v1"
+	"github.com/masonwr/CloudSecret/controllers"
+	"github.com/masonwr/CloudSecret/providers"
+	"github.com/masonwr/CloudSecret/providers/aws"
+	"github.com/masonwr/CloudSecret/providers/azure"
+	"github.com/masonwr/CloudSecret/providers/gcp"
+	"github.com/masonwr/CloudSecret/providers/vault"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	scheme   = runtime.NewScheme()
+	setupLog = ctrl.Log.WithName("setup")
+)
+
+func init() {
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = secretsv1.AddToScheme(scheme)
+}
+
+// enabledProviders is the set of provider schemes to wire up, configured via
+// the CLOUDSECRET_PROVIDERS env var (comma separated, defaults to "gcp" to
+// match pre-multi-provider behavior).
+func enabledProviders() []string {
+	raw := os.Getenv("CLOUDSECRET_PROVIDERS")
+	if raw == "" {
+		return []string{"gcp"}
+	}
+
+	return strings.Split(raw, ",")
+}
+
+func buildRegistry(ctx context.Context) (providers.Registry, error) {
+	registry := providers.Registry{}
+
+	for _, name := range enabledProviders() {
+		var (
+			p   providers.SecretProvider
+			err error
+		)
+
+		switch name {
+		case "gcp":
+			p, err = gcp.New(ctx, os.Getenv("GOOGLE_CLOUD_PROJECT"))
+		case "aws":
+			p, err = aws.New(ctx)
+		case "azure":
+			p, err = azure.New(ctx)
+		case "vault":
+			p, err = vault.New(ctx)
+		default:
+			setupLog.Info("ignoring unknown provider", "provider", name)
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		registry[p.Name()] = p
+	}
+
+	return registry, nil
+}
+
+func main() {
+	var metricsAddr string
+	var enableLeaderElection bool
+	var remotePollInterval time.Duration
+	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
+	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
+		"Enable leader election for controller manager.")
+	flag.DurationVar(&remotePollInterval, "remote-poll-interval", time.Minute,
+		"How often to poll referenced remote secrets for upstream rotation.")
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New())
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		Scheme:             scheme,
+		MetricsBindAddress: metricsAddr,
+		LeaderElection:     enableLeaderElection,
+		LeaderElectionID:   "cloudsecret-controller-lock",
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+
+	registry, err := buildRegistry(context.Background())
+	if err != nil {
+		setupLog.Error(err, "unable to initialize secret providers")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.CloudSecretReconciler{
+		Client:             mgr.GetClient(),
+		Log:                ctrl.Log.WithName("controllers").WithName("CloudSecret"),
+		Scheme:             mgr.GetScheme(),
+		Providers:          registry,
+		Recorder:           mgr.GetEventRecorderFor("cloudsecret-controller"),
+		RemotePollInterval: remotePollInterval,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CloudSecret")
+		os.Exit(1)
+	}
+
+	setupLog.Info("starting manager")
+	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+		setupLog.Error(err, "problem running manager")
+		os.Exit(1)
+	}
+}
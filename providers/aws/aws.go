@@ -0,0 +1,64 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package aws implements providers.SecretProvider on top of AWS Secrets
+// Manager.
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// Provider accesses secrets from AWS Secrets Manager. Refs are the secret
+// ARN or name, e.g. "arn:aws:secretsmanager:us-east-1:123456789012:secret:foo".
+type Provider struct {
+	client *secretsmanager.Client
+}
+
+// New creates a Provider backed by a new Secrets Manager client, using the
+// default AWS credential chain.
+func New(ctx context.Context) (*Provider, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{client: secretsmanager.NewFromConfig(cfg)}, nil
+}
+
+// Name implements providers.SecretProvider.
+func (p *Provider) Name() string {
+	return "aws"
+}
+
+// AccessSecret implements providers.SecretProvider.
+func (p *Provider) AccessSecret(ctx context.Context, ref string) ([]byte, error) {
+	out, err := p.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(ref),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.SecretBinary != nil {
+		return out.SecretBinary, nil
+	}
+
+	return []byte(aws.ToString(out.SecretString)), nil
+}
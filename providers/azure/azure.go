@@ -0,0 +1,74 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package azure implements providers.SecretProvider on top of Azure Key
+// Vault.
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/keyvault/azsecrets"
+)
+
+// Provider accesses secrets from Azure Key Vault. Refs have the form
+// "<vault>/<secret>/<version>", e.g. "my-vault/db-password/latest".
+type Provider struct {
+	cred azsecrets.Credential
+}
+
+// New creates a Provider using the default Azure credential chain.
+func New(ctx context.Context) (*Provider, error) {
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{cred: cred}, nil
+}
+
+// Name implements providers.SecretProvider.
+func (p *Provider) Name() string {
+	return "azure"
+}
+
+// AccessSecret implements providers.SecretProvider.
+func (p *Provider) AccessSecret(ctx context.Context, ref string) ([]byte, error) {
+	vault, name, version, err := splitRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	client := azsecrets.NewClient(fmt.Sprintf("https://%s.vault.azure.net", vault), p.cred, nil)
+
+	resp, err := client.GetSecret(ctx, name, version, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(*resp.Value), nil
+}
+
+func splitRef(ref string) (vault, name, version string, err error) {
+	parts := strings.SplitN(ref, "/", 3)
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("azure secret ref %q must be in the form <vault>/<secret>/<version>", ref)
+	}
+
+	return parts[0], parts[1], parts[2], nil
+}
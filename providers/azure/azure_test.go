@@ -0,0 +1,46 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import "testing"
+
+func TestSplitRef(t *testing.T) {
+	vault, name, version, err := splitRef("my-vault/db-password/latest")
+	if err != nil {
+		t.Fatalf("splitRef returned error: %v", err)
+	}
+	if vault != "my-vault" || name != "db-password" || version != "latest" {
+		t.Errorf("splitRef = (%q, %q, %q), want (%q, %q, %q)", vault, name, version, "my-vault", "db-password", "latest")
+	}
+}
+
+func TestSplitRefTooFewParts(t *testing.T) {
+	if _, _, _, err := splitRef("my-vault/db-password"); err == nil {
+		t.Error("splitRef with a missing version segment: expected error, got nil")
+	}
+}
+
+func TestSplitRefExtraSlashesKeptInVersion(t *testing.T) {
+	// splitRef splits on at most 3 "/"-separated parts, so anything past
+	// the name is left intact as the version segment.
+	_, _, version, err := splitRef("my-vault/db-password/v1/v2")
+	if err != nil {
+		t.Fatalf("splitRef returned error: %v", err)
+	}
+	if version != "v1/v2" {
+		t.Errorf("splitRef version = %q, want %q", version, "v1/v2")
+	}
+}
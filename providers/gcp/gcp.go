@@ -0,0 +1,133 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gcp implements providers.SecretProvider on top of GCP Secret
+// Manager.
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"google.golang.org/api/iterator"
+	secrets "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+
+	"github.com/masonwr/CloudSecret/providers"
+)
+
+// defaultMaxListedSecrets bounds ListSecrets when the caller doesn't
+// specify a max, guarding against runaway pagination over huge projects.
+const defaultMaxListedSecrets = 500
+
+// Provider accesses secrets from GCP Secret Manager. Refs are the full
+// resource name, e.g. "projects/my-proj/secrets/my-secret/versions/latest".
+type Provider struct {
+	client  *secretmanager.Client
+	project string
+}
+
+// New creates a Provider backed by a new Secret Manager client, using
+// application-default credentials. project scopes ListSecrets and is used
+// as given; an empty project is not filled in from the environment.
+func New(ctx context.Context, project string) (*Provider, error) {
+	client, err := secretmanager.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{client: client, project: project}, nil
+}
+
+// Name implements providers.SecretProvider.
+func (p *Provider) Name() string {
+	return "gcp"
+}
+
+// AccessSecret implements providers.SecretProvider.
+func (p *Provider) AccessSecret(ctx context.Context, ref string) ([]byte, error) {
+	access, err := p.client.AccessSecretVersion(ctx, &secrets.AccessSecretVersionRequest{Name: ref})
+	if err != nil {
+		return nil, err
+	}
+
+	return access.Payload.GetData(), nil
+}
+
+// ListSecrets implements providers.SecretLister, discovering secrets in
+// p.project whose labels match filter (Secret Manager's label-based
+// filter syntax, e.g. "labels.env=prod"). It pages through the full result
+// set but stops once max secrets have been collected (max <= 0 falls back
+// to defaultMaxListedSecrets).
+func (p *Provider) ListSecrets(ctx context.Context, filter string, max int64) ([]providers.ListedSecret, error) {
+	if max <= 0 {
+		max = defaultMaxListedSecrets
+	}
+
+	it := p.client.ListSecrets(ctx, &secrets.ListSecretsRequest{
+		Parent: fmt.Sprintf("projects/%s", p.project),
+		Filter: filter,
+	})
+
+	var found []providers.ListedSecret
+	for int64(len(found)) < max {
+		secret, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		found = append(found, providers.ListedSecret{
+			Ref:    secret.GetName() + "/versions/latest",
+			Labels: secret.GetLabels(),
+		})
+	}
+
+	return found, nil
+}
+
+// PurgeSecret implements providers.SecretPurger by disabling the
+// referenced secret version.
+func (p *Provider) PurgeSecret(ctx context.Context, ref string) error {
+	_, err := p.client.DisableSecretVersion(ctx, &secrets.DisableSecretVersionRequest{Name: ref})
+	return err
+}
+
+// LatestVersion implements providers.VersionWatcher by listing ref's parent
+// secret's versions and returning the resource name of the newest enabled
+// one, so the poller can detect upstream rotation between sync periods.
+func (p *Provider) LatestVersion(ctx context.Context, ref string) (string, error) {
+	parent := ref
+	if i := strings.LastIndex(ref, "/versions/"); i != -1 {
+		parent = ref[:i]
+	}
+
+	it := p.client.ListSecretVersions(ctx, &secrets.ListSecretVersionsRequest{Parent: parent})
+	for {
+		version, err := it.Next()
+		if err == iterator.Done {
+			return "", fmt.Errorf("no enabled versions found for %q", parent)
+		}
+		if err != nil {
+			return "", err
+		}
+		if version.GetState() == secrets.SecretVersion_ENABLED {
+			return version.GetName(), nil
+		}
+	}
+}
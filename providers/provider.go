@@ -0,0 +1,73 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package providers defines the SecretProvider interface implemented by each
+// backend (GCP Secret Manager, AWS Secrets Manager, Azure Key Vault,
+// HashiCorp Vault) that CloudSecretReconciler can draw secret data from.
+package providers
+
+import "context"
+
+// SecretProvider accesses secret payloads from a single backend. A ref is
+// the scheme-stripped remainder of a Spec.Data value, e.g. for
+// "aws://arn:aws:secretsmanager:..." the ref is
+// "arn:aws:secretsmanager:...".
+type SecretProvider interface {
+	// AccessSecret resolves ref to its current secret payload.
+	AccessSecret(ctx context.Context, ref string) ([]byte, error)
+
+	// Name returns the URL scheme this provider is registered under, e.g. "gcp".
+	Name() string
+}
+
+// Registry is a scheme -> SecretProvider lookup table used by the
+// reconciler to dispatch Spec.Data refs to the right backend.
+type Registry map[string]SecretProvider
+
+// ListedSecret is a single secret discovered via SecretLister.ListSecrets.
+type ListedSecret struct {
+	// Ref is the ref that would resolve this secret via AccessSecret, i.e.
+	// the scheme-stripped remainder of a Spec.Data value.
+	Ref string
+
+	// Labels are the tags/labels attached to the secret in the backend,
+	// used to filter and to derive the child Secret key via KeyTemplate.
+	Labels map[string]string
+}
+
+// SecretLister is implemented by providers that support discovering
+// secrets by label/tag filter instead of being referenced individually in
+// Spec.Data. Not all providers support this.
+type SecretLister interface {
+	// ListSecrets returns every secret matching filter, bounded by max (for
+	// a value <= 0 the bound is the provider's own default).
+	ListSecrets(ctx context.Context, filter string, max int64) ([]ListedSecret, error)
+}
+
+// SecretPurger is implemented by providers that support disabling or
+// destroying a secret upstream. Used when Spec.PurgeRemote is set on
+// deletion of the owning CloudSecret. Not all providers support this.
+type SecretPurger interface {
+	PurgeSecret(ctx context.Context, ref string) error
+}
+
+// VersionWatcher is implemented by providers that can report an opaque,
+// comparable version marker for a ref (e.g. the resolved version name).
+// The remote poller diffs this against the last-seen value to detect
+// upstream changes between sync periods, without needing a push-based
+// notification from the backend. Not all providers support this.
+type VersionWatcher interface {
+	LatestVersion(ctx context.Context, ref string) (string, error)
+}
@@ -0,0 +1,91 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vault implements providers.SecretProvider on top of HashiCorp
+// Vault's KV engine.
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// Provider accesses secrets from HashiCorp Vault. Refs have the form
+// "<path>#<key>", e.g. "secret/data/foo#password", where path is the KV
+// read path and key selects a field out of the returned secret data.
+type Provider struct {
+	client *vaultapi.Client
+}
+
+// New creates a Provider from the standard VAULT_ADDR / VAULT_TOKEN
+// environment configuration.
+func New(ctx context.Context) (*Provider, error) {
+	client, err := vaultapi.NewClient(vaultapi.DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{client: client}, nil
+}
+
+// Name implements providers.SecretProvider.
+func (p *Provider) Name() string {
+	return "vault"
+}
+
+// AccessSecret implements providers.SecretProvider.
+func (p *Provider) AccessSecret(ctx context.Context, ref string) ([]byte, error) {
+	path, key, err := splitRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := p.client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("no secret found at vault path %q", path)
+	}
+
+	data := secret.Data
+	if inner, ok := secret.Data["data"].(map[string]interface{}); ok {
+		data = inner
+	}
+
+	val, ok := data[key]
+	if !ok {
+		return nil, fmt.Errorf("vault secret %q has no key %q", path, key)
+	}
+
+	str, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("vault secret %q key %q is not a string", path, key)
+	}
+
+	return []byte(str), nil
+}
+
+func splitRef(ref string) (path, key string, err error) {
+	parts := strings.SplitN(ref, "#", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("vault secret ref %q must be in the form <path>#<key>", ref)
+	}
+
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,46 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import "testing"
+
+func TestSplitRef(t *testing.T) {
+	path, key, err := splitRef("secret/data/foo#password")
+	if err != nil {
+		t.Fatalf("splitRef returned error: %v", err)
+	}
+	if path != "secret/data/foo" || key != "password" {
+		t.Errorf("splitRef = (%q, %q), want (%q, %q)", path, key, "secret/data/foo", "password")
+	}
+}
+
+func TestSplitRefMissingHash(t *testing.T) {
+	if _, _, err := splitRef("secret/data/foo"); err == nil {
+		t.Error("splitRef with no \"#\" separator: expected error, got nil")
+	}
+}
+
+func TestSplitRefExtraHashKeptInKey(t *testing.T) {
+	// splitRef splits on the first "#" only, so a key containing one is
+	// left intact.
+	path, key, err := splitRef("secret/data/foo#pass#word")
+	if err != nil {
+		t.Fatalf("splitRef returned error: %v", err)
+	}
+	if path != "secret/data/foo" || key != "pass#word" {
+		t.Errorf("splitRef = (%q, %q), want (%q, %q)", path, key, "secret/data/foo", "pass#word")
+	}
+}